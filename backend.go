@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// BackendConfig 是配置文件中 "backends" 数组里每一项的结构。
+type BackendConfig struct {
+	Name        string `json:"name"`
+	ModelPrefix string `json:"model_prefix"`
+	BaseURL     string `json:"base_url"`
+	APIKey      string `json:"api_key"`
+	// AuthScheme 决定认证头怎么写，支持 "bearer"（默认）和 "x-api-key"（Anthropic 风格）。
+	AuthScheme string `json:"auth_scheme"`
+}
+
+// Backend 描述一个上游服务：它的地址、认证方式，以及把 OpenAI 请求/响应
+// 转换成该上游自己协议的 Translator。
+type Backend struct {
+	Name        string
+	ModelPrefix string
+	BaseURL     *url.URL
+	APIKey      string
+	AuthScheme  string
+	Translator  Translator
+	// UseKeyPool 为 true 时表示这是默认的 OpenAI 上游，沿用 keyPool 做轮询和故障转移。
+	UseKeyPool bool
+}
+
+// ApplyAuth 按该 Backend 的认证方案给上游请求设置认证头。
+func (b *Backend) ApplyAuth(req *http.Request) {
+	switch b.AuthScheme {
+	case "x-api-key":
+		req.Header.Set("x-api-key", b.APIKey)
+		req.Header.Del("Authorization")
+	default:
+		req.Header.Set("Authorization", "Bearer "+b.APIKey)
+	}
+}
+
+// backendRegistry 按配置顺序保存所有 Backend，openai 默认上游永远排在第一位。
+var backendRegistry []*Backend
+
+// buildBackendRegistry 根据配置构建 Backend 列表，openai 的默认上游始终存在。
+func buildBackendRegistry() []*Backend {
+	registry := []*Backend{
+		{
+			Name:        "openai",
+			ModelPrefix: "gpt-",
+			BaseURL:     openAIURL,
+			Translator:  passthroughTranslator{},
+			UseKeyPool:  true,
+		},
+	}
+
+	for _, cfg := range config.Backends {
+		backendURL, err := url.Parse(cfg.BaseURL)
+		if err != nil {
+			logger.Printf("解析 Backend %q 的 base_url 时出错: %v", cfg.Name, err)
+			continue
+		}
+		registry = append(registry, &Backend{
+			Name:        cfg.Name,
+			ModelPrefix: cfg.ModelPrefix,
+			BaseURL:     backendURL,
+			APIKey:      cfg.APIKey,
+			AuthScheme:  cfg.AuthScheme,
+			Translator:  translatorForBackend(cfg.Name),
+		})
+	}
+
+	return registry
+}
+
+// translatorForBackend 根据 Backend 名字选择对应的请求/响应转换器。
+func translatorForBackend(name string) Translator {
+	switch name {
+	case "anthropic":
+		return anthropicTranslator{}
+	case "ollama":
+		return ollamaTranslator{}
+	default:
+		return passthroughTranslator{}
+	}
+}
+
+// resolveBackend 按请求体里的 model 字段前缀匹配 Backend，匹配不到则回退到默认的 openai 上游。
+func resolveBackend(model string) *Backend {
+	for _, b := range backendRegistry {
+		if b.ModelPrefix != "" && strings.HasPrefix(model, b.ModelPrefix) {
+			return b
+		}
+	}
+	return backendRegistry[0]
+}
+
+// extractModel 从请求体中读取 model 字段，读取失败时返回空字符串（落到默认 Backend）。
+func extractModel(body []byte) string {
+	var payload struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return payload.Model
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultCacheTTL            = 5 * time.Minute
+	defaultCacheMaxEntryBytes  = 256 * 1024
+	defaultCacheMaxEntries     = 1000
+	defaultCacheTemperatureMax = 0
+)
+
+// CacheConfig 是配置文件中的 "cache" 节点。
+type CacheConfig struct {
+	Enabled              bool     `json:"enabled"`
+	TTLSeconds           int      `json:"ttl_seconds"`
+	MaxEntryBytes        int      `json:"max_entry_bytes"`
+	MaxEntries           int      `json:"max_entries"`
+	TemperatureThreshold float64  `json:"temperature_threshold"`
+	RedisAddr            string   `json:"redis_addr"`
+	DisabledModels       []string `json:"disabled_models"`
+}
+
+func (cfg CacheConfig) ttl() time.Duration {
+	if cfg.TTLSeconds <= 0 {
+		return defaultCacheTTL
+	}
+	return time.Duration(cfg.TTLSeconds) * time.Second
+}
+
+func (cfg CacheConfig) maxEntryBytes() int {
+	if cfg.MaxEntryBytes <= 0 {
+		return defaultCacheMaxEntryBytes
+	}
+	return cfg.MaxEntryBytes
+}
+
+// temperatureThreshold 返回判定请求是否"确定性"的 temperature 上限，默认只缓存 temperature == 0 的请求。
+func (cfg CacheConfig) temperatureThreshold() float64 {
+	if cfg.TemperatureThreshold > 0 {
+		return cfg.TemperatureThreshold
+	}
+	return defaultCacheTemperatureMax
+}
+
+// disabledFor 判断 model 是否被配置为不走缓存。
+func (cfg CacheConfig) disabledFor(model string) bool {
+	return containsString(cfg.DisabledModels, model)
+}
+
+// Cache 是响应缓存的存储接口，分别有内存 LRU 和 Redis 两种实现。
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+}
+
+// buildCache 根据配置选择缓存实现：配置了 redis_addr 用 Redis，否则用内存 LRU。
+func buildCache(cfg CacheConfig) Cache {
+	if cfg.RedisAddr != "" {
+		return NewRedisCache(cfg.RedisAddr)
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return NewLRUCache(maxEntries)
+}
+
+// ---- 内存 LRU ----
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRUCache 是一个支持 TTL 过期的内存 LRU 缓存。
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+// NewLRUCache 创建一个最多保存 maxEntries 条目的内存缓存。
+func NewLRUCache(maxEntries int) *LRUCache {
+	return &LRUCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUCache) Get(_ context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *LRUCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.ll.MoveToFront(elem)
+		elem.Value.(*lruEntry).value = value
+		elem.Value.(*lruEntry).expiresAt = time.Now().Add(ttl)
+		return
+	}
+
+	elem := c.ll.PushFront(&lruEntry{key: key, value: value, expiresAt: time.Now().Add(ttl)})
+	c.items[key] = elem
+
+	for c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// ---- Redis ----
+
+// RedisCache 把响应缓存存到 Redis，适合多个代理实例共享缓存的场景。
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache 连接到 addr 指向的 Redis 实例。
+func NewRedisCache(addr string) *RedisCache {
+	return &RedisCache{client: redis.NewClient(&redis.Options{Addr: addr})}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if err := c.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		logger.Printf("写入 Redis 缓存时出错: %v", err)
+	}
+}
+
+// cacheKeyForRequest 对可以被缓存的请求算出缓存 key。
+// 只有显式传了 temperature 且不超过 threshold 的非流式请求才认为是确定性的、可以缓存；
+// OpenAI 的默认 temperature 是 1（非确定性的），所以客户端没传 temperature 时绝不能当作 0 处理。
+func cacheKeyForRequest(body []byte, threshold float64) (key string, cacheable bool) {
+	var req struct {
+		Model       string          `json:"model"`
+		Messages    json.RawMessage `json:"messages"`
+		Temperature *float64        `json:"temperature"`
+		TopP        *float64        `json:"top_p"`
+		Tools       json.RawMessage `json:"tools"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", false
+	}
+
+	if req.Temperature == nil || *req.Temperature > threshold {
+		return "", false
+	}
+	temperature := *req.Temperature
+
+	topP := 0.0
+	if req.TopP != nil {
+		topP = *req.TopP
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%.4f|%.4f|%s", req.Model, string(req.Messages), temperature, topP, string(req.Tools))
+	return hex.EncodeToString(h.Sum(nil)), true
+}
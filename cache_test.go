@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCacheKeyForRequestMissingTemperatureIsNotCacheable(t *testing.T) {
+	body := []byte(`{"model":"gpt-4","messages":[{"role":"user","content":"hi"}]}`)
+
+	_, cacheable := cacheKeyForRequest(body, 0)
+	if cacheable {
+		t.Fatal("未显式传 temperature 时不应被当作确定性请求缓存（OpenAI 默认 temperature 是 1）")
+	}
+}
+
+func TestCacheKeyForRequestAboveThresholdIsNotCacheable(t *testing.T) {
+	body := []byte(`{"model":"gpt-4","messages":[{"role":"user","content":"hi"}],"temperature":0.7}`)
+
+	_, cacheable := cacheKeyForRequest(body, 0)
+	if cacheable {
+		t.Fatal("temperature 超过 threshold 时不应被认为可缓存")
+	}
+}
+
+func TestCacheKeyForRequestAtOrBelowThresholdIsCacheable(t *testing.T) {
+	body := []byte(`{"model":"gpt-4","messages":[{"role":"user","content":"hi"}],"temperature":0}`)
+
+	key, cacheable := cacheKeyForRequest(body, 0)
+	if !cacheable || key == "" {
+		t.Fatal("temperature 为 0 且不超过 threshold 时应该可缓存")
+	}
+}
+
+func TestCacheKeyForRequestIsDeterministic(t *testing.T) {
+	body := []byte(`{"model":"gpt-4","messages":[{"role":"user","content":"hi"}],"temperature":0,"top_p":0.9}`)
+
+	key1, ok1 := cacheKeyForRequest(body, 0)
+	key2, ok2 := cacheKeyForRequest(body, 0)
+	if !ok1 || !ok2 || key1 != key2 {
+		t.Fatalf("相同请求应该产生相同的缓存 key，得到 %q 和 %q", key1, key2)
+	}
+}
+
+func TestCacheKeyForRequestDiffersByMessages(t *testing.T) {
+	bodyA := []byte(`{"model":"gpt-4","messages":[{"role":"user","content":"hi"}],"temperature":0}`)
+	bodyB := []byte(`{"model":"gpt-4","messages":[{"role":"user","content":"bye"}],"temperature":0}`)
+
+	keyA, _ := cacheKeyForRequest(bodyA, 0)
+	keyB, _ := cacheKeyForRequest(bodyB, 0)
+	if keyA == keyB {
+		t.Fatal("不同的 messages 应该产生不同的缓存 key")
+	}
+}
+
+func TestLRUCacheGetSetAndEviction(t *testing.T) {
+	cache := NewLRUCache(1)
+	ctx := context.Background()
+
+	cache.Set(ctx, "a", []byte("1"), defaultCacheTTL)
+	cache.Set(ctx, "b", []byte("2"), defaultCacheTTL)
+
+	if _, ok := cache.Get(ctx, "a"); ok {
+		t.Fatal("超过 maxEntries 后最旧的条目应该被淘汰")
+	}
+	if v, ok := cache.Get(ctx, "b"); !ok || string(v) != "2" {
+		t.Fatal("最近写入的条目应该仍然可以取到")
+	}
+}
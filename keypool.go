@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyList 支持配置文件中 openai_api_key 写成单个字符串、
+// 用竖线("|")分隔的多个 Key，或 JSON 数组形式。
+type APIKeyList []string
+
+// UnmarshalJSON 兼容 "key1|key2|key3"、"key1" 以及 ["key1","key2"] 三种写法。
+func (k *APIKeyList) UnmarshalJSON(data []byte) error {
+	var asArray []string
+	if err := json.Unmarshal(data, &asArray); err == nil {
+		*k = asArray
+		return nil
+	}
+
+	var asString string
+	if err := json.Unmarshal(data, &asString); err != nil {
+		return err
+	}
+
+	var keys []string
+	for _, part := range strings.Split(asString, "|") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			keys = append(keys, part)
+		}
+	}
+	*k = keys
+	return nil
+}
+
+const (
+	// keyFailureThreshold 是某个 Key 连续失败多少次后被隔离。
+	keyFailureThreshold = 3
+	// keyQuarantineDuration 是 Key 被隔离后多久重新参与轮询。
+	keyQuarantineDuration = 30 * time.Second
+)
+
+// keyState 记录单个上游 Key 的健康状态。
+type keyState struct {
+	key              string
+	consecutiveFails int
+	successCount     int
+	failureCount     int
+	quarantinedUntil time.Time
+	lastStatus       int
+	lastUsed         time.Time
+}
+
+// KeyPool 在多个上游 Key 之间做轮询、健康检查与隔离。
+// 所有方法都是并发安全的。
+type KeyPool struct {
+	mu    sync.Mutex
+	keys  []*keyState
+	index int
+}
+
+// NewKeyPool 根据配置中的 Key 列表创建一个 KeyPool。
+func NewKeyPool(keys []string) *KeyPool {
+	pool := &KeyPool{}
+	for _, k := range keys {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		pool.keys = append(pool.keys, &keyState{key: k})
+	}
+	return pool
+}
+
+// Len 返回池中配置的 Key 数量（不区分健康状态）。
+func (p *KeyPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.keys)
+}
+
+// Next 以轮询的方式返回下一个健康的 Key。
+// 如果所有 Key 都处于隔离状态，则退化为返回隔离到期时间最早的 Key（避免完全不可用）。
+func (p *KeyPool) Next() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.keys)
+	if n == 0 {
+		return "", false
+	}
+
+	now := time.Now()
+	for i := 0; i < n; i++ {
+		idx := (p.index + i) % n
+		state := p.keys[idx]
+		if state.quarantinedUntil.IsZero() || state.quarantinedUntil.Before(now) {
+			p.index = (idx + 1) % n
+			state.lastUsed = now
+			return state.key, true
+		}
+	}
+
+	// 所有 Key 都被隔离，选择隔离到期时间最早的那个，保证服务不完全中断。
+	earliest := p.keys[0]
+	for _, state := range p.keys[1:] {
+		if state.quarantinedUntil.Before(earliest.quarantinedUntil) {
+			earliest = state
+		}
+	}
+	earliest.lastUsed = now
+	return earliest.key, true
+}
+
+// RecordResult 记录一次针对 key 的上游调用结果。
+// statusCode 为 0 表示请求本身失败（网络错误等）。
+func (p *KeyPool) RecordResult(key string, statusCode int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, state := range p.keys {
+		if state.key != key {
+			continue
+		}
+		state.lastStatus = statusCode
+		if statusCode == 0 || isRetryableStatus(statusCode) {
+			state.failureCount++
+			state.consecutiveFails++
+			if state.consecutiveFails >= keyFailureThreshold {
+				state.quarantinedUntil = time.Now().Add(keyQuarantineDuration)
+			}
+		} else {
+			state.successCount++
+			state.consecutiveFails = 0
+			state.quarantinedUntil = time.Time{}
+		}
+		return
+	}
+}
+
+// isRetryableStatus 判断上游返回的状态码是否值得换一个 Key 重试。
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case 401, 429, 500, 502, 503, 504:
+		return true
+	default:
+		return false
+	}
+}
+
+// KeyStateSnapshot 是 /admin/keys 返回的对外可见状态，Key 本身做了脱敏处理。
+type KeyStateSnapshot struct {
+	MaskedKey    string    `json:"masked_key"`
+	Healthy      bool      `json:"healthy"`
+	SuccessCount int       `json:"success_count"`
+	FailureCount int       `json:"failure_count"`
+	LastStatus   int       `json:"last_status"`
+	LastUsed     time.Time `json:"last_used,omitempty"`
+}
+
+// Snapshot 返回池中每个 Key 的脱敏状态，供 /admin/keys 使用。
+func (p *KeyPool) Snapshot() []KeyStateSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	snapshots := make([]KeyStateSnapshot, 0, len(p.keys))
+	for _, state := range p.keys {
+		snapshots = append(snapshots, KeyStateSnapshot{
+			MaskedKey:    maskKey(state.key),
+			Healthy:      state.quarantinedUntil.IsZero() || state.quarantinedUntil.Before(now),
+			SuccessCount: state.successCount,
+			FailureCount: state.failureCount,
+			LastStatus:   state.lastStatus,
+			LastUsed:     state.lastUsed,
+		})
+	}
+	return snapshots
+}
+
+// maskKey 只保留 Key 首尾几位，避免在 /admin/keys 中泄露完整密钥。
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:4] + strings.Repeat("*", len(key)-8) + key[len(key)-4:]
+}
+
+// keyPoolTransport 包装底层 http.RoundTripper，在 Key 池中选择 Key，
+// 并在上游返回可重试的状态码时换一个健康的 Key 重试。
+type keyPoolTransport struct {
+	base        http.RoundTripper
+	pool        *KeyPool
+	maxAttempts int
+}
+
+func (t *keyPoolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var bodyBytes []byte
+	if req.Body != nil {
+		var err error
+		bodyBytes, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	attempts := t.maxAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	var lastResp *http.Response
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		key, ok := t.pool.Next()
+		if !ok {
+			break
+		}
+
+		if bodyBytes != nil {
+			req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+			req.ContentLength = int64(len(bodyBytes))
+		}
+		req.Header.Set("Authorization", "Bearer "+key)
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			t.pool.RecordResult(key, 0)
+			lastErr = err
+			continue
+		}
+
+		t.pool.RecordResult(key, resp.StatusCode)
+		if !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		if lastResp != nil {
+			lastResp.Body.Close()
+		}
+		lastResp = resp
+	}
+
+	if lastResp != nil {
+		return lastResp, nil
+	}
+	return nil, lastErr
+}
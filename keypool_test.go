@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestKeyPoolNextRoundRobin(t *testing.T) {
+	pool := NewKeyPool([]string{"key-a", "key-b"})
+
+	first, ok := pool.Next()
+	if !ok {
+		t.Fatal("期望 Next 返回一个 Key")
+	}
+	second, _ := pool.Next()
+	if first == second {
+		t.Fatalf("轮询应该在不同 Key 之间切换，两次都得到 %q", first)
+	}
+	third, _ := pool.Next()
+	if third != first {
+		t.Fatalf("轮询两个 Key 一圈后应该回到起点，得到 %q, 期望 %q", third, first)
+	}
+}
+
+func TestKeyPoolQuarantineAfterConsecutiveFailures(t *testing.T) {
+	pool := NewKeyPool([]string{"key-a", "key-b"})
+
+	for i := 0; i < keyFailureThreshold; i++ {
+		pool.RecordResult("key-a", 500)
+	}
+
+	for i := 0; i < 10; i++ {
+		key, ok := pool.Next()
+		if !ok {
+			t.Fatal("期望 Next 返回一个 Key")
+		}
+		if key == "key-a" {
+			t.Fatal("key-a 连续失败达到阈值后应该被隔离，不应再被轮询到")
+		}
+	}
+}
+
+func TestKeyPoolRecoversAfterSuccess(t *testing.T) {
+	pool := NewKeyPool([]string{"key-a"})
+
+	for i := 0; i < keyFailureThreshold; i++ {
+		pool.RecordResult("key-a", 500)
+	}
+	pool.RecordResult("key-a", 200)
+
+	key, ok := pool.Next()
+	if !ok || key != "key-a" {
+		t.Fatalf("成功一次后应该清除隔离状态，Next() = (%q, %v)", key, ok)
+	}
+}
+
+func TestKeyPoolFallsBackWhenAllQuarantined(t *testing.T) {
+	pool := NewKeyPool([]string{"key-a"})
+
+	for i := 0; i < keyFailureThreshold; i++ {
+		pool.RecordResult("key-a", 500)
+	}
+
+	key, ok := pool.Next()
+	if !ok || key != "key-a" {
+		t.Fatalf("所有 Key 都被隔离时仍应退化返回一个 Key，得到 (%q, %v)", key, ok)
+	}
+}
+
+func TestIsRetryableStatus(t *testing.T) {
+	cases := map[int]bool{200: false, 400: false, 401: true, 429: true, 500: true, 503: true}
+	for status, want := range cases {
+		if got := isRetryableStatus(status); got != want {
+			t.Errorf("isRetryableStatus(%d) = %v, 期望 %v", status, got, want)
+		}
+	}
+}
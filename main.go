@@ -2,30 +2,54 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"flag"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"testing"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/wmc1125/openai_agent_simple/store"
 )
 
 var (
-	openAIURL *url.URL
-	logger    *log.Logger
-	config    struct {
-		OpenAIAPIURL        string            `json:"openai_api_url"`
-		OpenAIAPIKey        string            `json:"openai_api_key"`
-		PromptModifications map[string]string `json:"prompt_modifications"`
+	openAIURL   *url.URL
+	logger      *log.Logger
+	keyPool     *KeyPool
+	promptRules []*PromptRule
+	reqStore    *store.Store
+	rateLimiter *RateLimiter
+	// respCache 为 nil 表示未启用响应缓存（config.Cache.Enabled 为 false）。
+	respCache Cache
+	// dryRun 由 --dry-run 命令行参数控制，为 true 时只记录提示词规则会产生的改动，不真正修改出站请求体。
+	dryRun bool
+	config struct {
+		OpenAIAPIURL string             `json:"openai_api_url"`
+		OpenAIAPIKey APIKeyList         `json:"openai_api_key"`
+		PromptRules  []PromptRule       `json:"prompt_rules"`
+		Backends     []BackendConfig    `json:"backends"`
+		StorePath    string             `json:"store_path"`
+		RateLimiting RateLimitingConfig `json:"rate_limiting"`
+		Cache        CacheConfig        `json:"cache"`
 	}
 )
 
 func init() {
+	// 单元测试不依赖 config.json/磁盘上的真实配置，测试自己准备所需的全局状态。
+	// 用 testing.Testing() 而不是猜测二进制文件名，这样不管测试二进制叫什么名字都能可靠识别。
+	if testing.Testing() {
+		return
+	}
+
 	// 读取配置文件
 	configFile, err := os.ReadFile("config.json")
 	if err != nil {
@@ -43,17 +67,53 @@ func init() {
 		log.Fatal("解析OpenAI API URL时出错:", err)
 	}
 
+	// 初始化 Key 池，支持配置多个上游 Key 做轮询和故障转移
+	keyPool = NewKeyPool(config.OpenAIAPIKey)
+	if keyPool.Len() == 0 {
+		log.Fatal("配置文件中未提供任何 openai_api_key")
+	}
+
 	// 初始化日志记录器
 	logFile, err := os.OpenFile("requests.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		log.Fatal("打开日志文件时出错:", err)
 	}
 	logger = log.New(logFile, "", log.LstdFlags)
+
+	// 按 model 前缀构建上游 Backend 注册表，默认始终包含 openai 自身
+	backendRegistry = buildBackendRegistry()
+
+	// 编译提示词规则管道
+	promptRules = compilePromptRules(config.PromptRules)
+
+	// 初始化请求/响应持久化存储
+	storePath := config.StorePath
+	if storePath == "" {
+		storePath = "requests.db"
+	}
+	reqStore, err = store.Open(storePath)
+	if err != nil {
+		log.Fatal("打开请求存储数据库时出错:", err)
+	}
+
+	// 初始化按客户端 + model 的限流器
+	rateLimiter = NewRateLimiter(config.RateLimiting)
+
+	// 对确定性的非流式补全（temperature 很低）启用响应缓存
+	if config.Cache.Enabled {
+		respCache = buildCache(config.Cache)
+	}
 }
 
 func main() {
+	flag.BoolVar(&dryRun, "dry-run", false, "只记录提示词规则会产生的改动，不修改出站请求体")
+	flag.Parse()
+
 	r := gin.Default()
 
+	// /metrics 注册在 CORS 中间件之前，这样它既不受 CORS 限制，也不会经过 handleProxy 里的提示词处理管道
+	r.GET("/metrics", metricsHandler())
+
 	// 配置CORS中间件
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"http://localhost:5173"} // 指定允许的源
@@ -63,8 +123,17 @@ func main() {
 	corsConfig.AllowCredentials = true // 允许凭证
 	r.Use(cors.New(corsConfig))
 
-	// 允许所有HTTP方法
-	r.Any("/*path", handleProxy)
+	// 查看 Key 池状态（Key 已脱敏）
+	r.GET("/admin/keys", handleAdminKeys)
+
+	// 查询持久化的请求/响应记录与统计信息
+	r.GET("/admin/requests", handleAdminListRequests)
+	r.GET("/admin/requests/:id", handleAdminGetRequest)
+	r.GET("/admin/stats", handleAdminStats)
+
+	// 用 NoRoute 而不是 r.Any("/*path", ...) 作为代理的兜底：
+	// gin 的路由树不允许通配符 "*path" 和 "/admin"、"/metrics" 这样的静态前缀共存，会在启动时 panic。
+	r.NoRoute(rateLimitMiddleware(rateLimiter), handleProxy)
 
 	log.Println("服务器启动，监听端口 :8080")
 	if err := r.Run(":8080"); err != nil {
@@ -72,34 +141,32 @@ func main() {
 	}
 }
 
+// requestMetaKey 用于把 requestMeta 挂到出站请求的 context 上，供 logResponse 打点使用。
+type requestMetaKey struct{}
+
+// requestMeta 携带一次请求在各处理阶段之间需要共享的上下文，用于最终写入 reqStore。
+type requestMeta struct {
+	method  string
+	path    string
+	model   string
+	backend string
+	start   time.Time
+	body    []byte
+}
+
 func handleProxy(c *gin.Context) {
 	logger.Printf("收到请求: %s %s", c.Request.Method, c.Request.URL.Path)
 	logger.Printf("请求头: %v", c.Request.Header)
 
-	// 创建反向代理
-	proxy := httputil.NewSingleHostReverseProxy(openAIURL)
+	start := time.Now()
 
-	// 修改请求
-	director := proxy.Director
-	proxy.Director = func(req *http.Request) {
-		director(req)
-		req.Host = openAIURL.Host
-
-		// 使用用户传过来的 API Key
-		userAPIKey := c.GetHeader("Authorization")
-		if userAPIKey != "" {
-			req.Header.Set("Authorization", userAPIKey)
-		} else {
-			// 如果用户没有提供 API Key，则使用配置文件中的 Key
-			req.Header.Set("Authorization", "Bearer "+config.OpenAIAPIKey)
-		}
-
-		// 不再手动删除CORS头，以避免重复
+	// 处理 OPTIONS 请求
+	if c.Request.Method == "OPTIONS" {
+		// CORS中间件已经处理了CORS头，这里只需返回200即可
+		c.Status(http.StatusOK)
+		return
 	}
 
-	// 设置 ModifyResponse 仅用于日志记录，不处理CORS
-	proxy.ModifyResponse = logResponse
-
 	// 捕获并修改请求体
 	var requestBody []byte
 	var err error
@@ -110,32 +177,160 @@ func handleProxy(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "内部服务器错误"})
 			return
 		}
-		modifiedBody := modifyRequestBody(requestBody)
-		c.Request.Body = io.NopCloser(bytes.NewBuffer(modifiedBody))
-		c.Request.ContentLength = int64(len(modifiedBody))
 	}
+	modifiedBody := modifyRequestBody(requestBody)
 
 	// 记录请求
 	logger.Printf("请求: %s %s\n请求体: %s", c.Request.Method, c.Request.URL.Path, string(requestBody))
 
-	// 处理 OPTIONS 请求
-	if c.Request.Method == "OPTIONS" {
-		// CORS中间件已经处理了CORS头，这里只需返回200即可
-		c.Status(http.StatusOK)
-		return
+	// 根据请求体里的 model 字段选择上游 Backend
+	model := extractModel(modifiedBody)
+	backend := resolveBackend(model)
+	meta := requestMeta{
+		method:  c.Request.Method,
+		path:    c.Request.URL.Path,
+		model:   model,
+		backend: backend.Name,
+		start:   start,
+		body:    modifiedBody,
+	}
+
+	translatedBody, err := backend.Translator.TranslateRequestBody(modifiedBody)
+	if err != nil {
+		logger.Printf("转换请求体到 Backend %q 格式时出错: %v", backend.Name, err)
+		translatedBody = modifiedBody
 	}
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(translatedBody))
+	c.Request.ContentLength = int64(len(translatedBody))
+
+	// 创建反向代理
+	proxy := httputil.NewSingleHostReverseProxy(backend.BaseURL)
+
+	// 用户传过来的 API Key 始终优先，且对用户不可见地选 Key 的逻辑完全不生效
+	userAPIKey := c.GetHeader("Authorization")
+
+	// 修改请求
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = backend.BaseURL.Host
+		// NewSingleHostReverseProxy 只会把客户端的原始路径（如 /v1/chat/completions）拼到 BaseURL 后面，
+		// 对于 Anthropic/Ollama 这类有自己 REST 端点的上游需要改写成它们自己的路径。
+		if upstreamPath := backend.Translator.UpstreamPath(); upstreamPath != "" {
+			req.URL.Path = upstreamPath
+			req.URL.RawPath = ""
+		}
+		*req = *req.WithContext(context.WithValue(req.Context(), requestMetaKey{}, meta))
+
+		if userAPIKey != "" {
+			// 使用用户传过来的 API Key
+			req.Header.Set("Authorization", userAPIKey)
+		} else if backend.UseKeyPool {
+			// Authorization 头留给 keyPoolTransport 按需填充，这样每次重试都可以换一个健康的上游 Key。
+		} else {
+			backend.ApplyAuth(req)
+		}
+
+		// 不再手动删除CORS头，以避免重复
+	}
+
+	if userAPIKey == "" && backend.UseKeyPool {
+		// 没有用户 Key 时，在 Key 池中轮询并在遇到可重试的错误时换 Key 重试
+		proxy.Transport = &keyPoolTransport{
+			base:        http.DefaultTransport,
+			pool:        keyPool,
+			maxAttempts: keyPool.Len(),
+		}
+	}
+
+	// 设置 ModifyResponse 用于日志记录，并把非流式响应转换回 OpenAI 格式
+	proxy.ModifyResponse = makeModifyResponse(backend.Translator)
 
 	// 检查是否为流式请求
 	isStreamRequest := strings.Contains(c.Request.URL.Path, "/stream") || (c.Request.Header.Get("Accept") == "text/event-stream")
 
 	if isStreamRequest {
-		handleStreamRequest(c, proxy)
+		handleStreamRequest(c, proxy, backend.Translator, meta)
 	} else {
-		handleNonStreamRequest(c, proxy)
+		handleNonStreamRequest(c, proxy, meta)
+	}
+}
+
+func handleAdminKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"keys": keyPool.Snapshot()})
+}
+
+func handleAdminListRequests(c *gin.Context) {
+	var f store.Filter
+	f.Model = c.Query("model")
+
+	if statusStr := c.Query("status"); statusStr != "" {
+		if v, err := strconv.Atoi(statusStr); err == nil {
+			f.StatusCode = v
+		}
+	}
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		if t, err := time.Parse(time.RFC3339, sinceStr); err == nil {
+			f.Since = t
+		}
+	}
+	if untilStr := c.Query("until"); untilStr != "" {
+		if t, err := time.Parse(time.RFC3339, untilStr); err == nil {
+			f.Until = t
+		}
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if v, err := strconv.Atoi(limitStr); err == nil && v > 0 {
+			limit = v
+		}
+	}
+
+	records, err := reqStore.List(f, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"requests": records})
+}
+
+func handleAdminGetRequest(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 id"})
+		return
 	}
+
+	record, err := reqStore.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if record == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到记录"})
+		return
+	}
+	c.JSON(http.StatusOK, record)
 }
 
-func handleStreamRequest(c *gin.Context, proxy *httputil.ReverseProxy) {
+func handleAdminStats(c *gin.Context) {
+	since := time.Now().AddDate(0, 0, -30)
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		if t, err := time.Parse(time.RFC3339, sinceStr); err == nil {
+			since = t
+		}
+	}
+
+	stats, err := reqStore.Stats(since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+func handleStreamRequest(c *gin.Context, proxy *httputil.ReverseProxy, translator Translator, meta requestMeta) {
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
@@ -148,17 +343,89 @@ func handleStreamRequest(c *gin.Context, proxy *httputil.ReverseProxy) {
 	proxyWriter := &streamResponseWriter{
 		ResponseWriter: responseWriter,
 		logger:         logger,
+		translator:     translator,
 	}
 
+	activeStreams.WithLabelValues(meta.model, meta.backend).Inc()
 	proxy.ServeHTTP(proxyWriter, c.Request)
+	activeStreams.WithLabelValues(meta.model, meta.backend).Dec()
+
+	observeRequest(meta, http.StatusOK)
+	streamChunksTotal.WithLabelValues(meta.model, meta.backend).Add(float64(proxyWriter.chunkCount))
+
+	if reqStore != nil {
+		if _, err := reqStore.Insert(store.Record{
+			Method:           meta.method,
+			Path:             meta.path,
+			Model:            meta.model,
+			Backend:          meta.backend,
+			StatusCode:       http.StatusOK,
+			LatencyMS:        time.Since(meta.start).Milliseconds(),
+			RequestBody:      string(meta.body),
+			ResponseBody:     proxyWriter.content.String(),
+			StreamChunkCount: proxyWriter.chunkCount,
+		}); err != nil {
+			logger.Printf("写入请求存储时出错: %v", err)
+		}
+	}
 }
 
 type streamResponseWriter struct {
 	gin.ResponseWriter
-	logger *log.Logger
+	logger     *log.Logger
+	translator Translator
+	// chunkCount 和 content 供持久化使用：记录已转发的数据块数量，以及把每个 delta 拼接起来的完整回复。
+	chunkCount int
+	content    bytes.Buffer
 }
 
 func (w *streamResponseWriter) Write(p []byte) (int, error) {
+	// passthroughTranslator 不需要改写数据块，原样转发（沿用原有行为）。
+	if _, isPassthrough := w.translator.(passthroughTranslator); w.translator == nil || isPassthrough {
+		w.logChunks(p)
+		return w.ResponseWriter.Write(p)
+	}
+
+	var out bytes.Buffer
+	lines := bytes.Split(p, []byte("\n"))
+	for _, line := range lines {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		trimmedLine := bytes.TrimPrefix(line, []byte("data: "))
+		if bytes.Equal(trimmedLine, []byte("[DONE]")) {
+			w.logger.Printf("流式传输结束")
+			continue
+		}
+
+		translated, ok, err := w.translator.TranslateStreamChunk(trimmedLine)
+		if err != nil {
+			w.logger.Printf("转换流式数据块时出错: %v", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if bytes.Equal(translated, streamDone) {
+			out.WriteString("data: [DONE]\n\n")
+			continue
+		}
+		w.chunkCount++
+		w.content.WriteString(extractDeltaContent(translated))
+		out.WriteString("data: ")
+		out.Write(translated)
+		out.WriteString("\n\n")
+	}
+
+	if _, err := w.ResponseWriter.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// logChunks 记录每个 SSE 数据块，复用于不需要转换的 Backend（如默认的 OpenAI 上游）。
+func (w *streamResponseWriter) logChunks(p []byte) {
 	lines := bytes.Split(p, []byte("\n"))
 	for _, line := range lines {
 		line = bytes.TrimSpace(line)
@@ -176,9 +443,25 @@ func (w *streamResponseWriter) Write(p []byte) (int, error) {
 			w.logger.Printf("解析流式数据时出错: %v", err)
 			continue
 		}
+		w.chunkCount++
+		w.content.WriteString(extractDeltaContent(trimmedLine))
 		w.logger.Printf("流式数据块: %s", string(trimmedLine))
 	}
-	return w.ResponseWriter.Write(p)
+}
+
+// extractDeltaContent 从一个 OpenAI 风格的 SSE chunk 中取出 delta.content，解析失败时返回空字符串。
+func extractDeltaContent(chunk []byte) string {
+	var payload struct {
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(chunk, &payload); err != nil || len(payload.Choices) == 0 {
+		return ""
+	}
+	return payload.Choices[0].Delta.Content
 }
 
 type responseBodyWriter struct {
@@ -205,30 +488,15 @@ func modifyRequestBody(body []byte) []byte {
 		return body
 	}
 
-	if messages, ok := request["messages"].([]interface{}); ok {
-		for i, msg := range messages {
-			if message, ok := msg.(map[string]interface{}); ok {
-				if content, ok := message["content"].(string); ok {
-					// 记录原始提示词
-					logger.Printf("原始提示词: %s", content)
-
-					// 修改提示词
-					originalContent := content
-					for keyword, replacement := range config.PromptModifications {
-						content = strings.ReplaceAll(content, keyword, replacement)
-					}
+	model, _ := request["model"].(string)
 
-					// 只有在内容被修改时才记录修改后的提示词
-					if content != originalContent {
-						logger.Printf("修改后的提示词: %s", content)
-					}
+	if messages, ok := request["messages"].([]interface{}); ok {
+		request["messages"] = evaluatePromptRules(promptRules, model, messages, dryRun)
+	}
 
-					message["content"] = content
-					messages[i] = message
-				}
-			}
-		}
-		request["messages"] = messages
+	if dryRun {
+		// dry-run 模式只记录规则会产生的改动，出站请求体保持不变
+		return body
 	}
 
 	modifiedBody, err := json.Marshal(request)
@@ -246,6 +514,12 @@ func logResponse(resp *http.Response) error {
 	resp.Header.Del("Access-Control-Allow-Methods")
 	resp.Header.Del("Access-Control-Allow-Headers")
 
+	if resp.StatusCode >= http.StatusBadRequest {
+		if meta, ok := resp.Request.Context().Value(requestMetaKey{}).(requestMeta); ok {
+			upstreamErrorsTotal.WithLabelValues(meta.method, meta.path, meta.model, strconv.Itoa(resp.StatusCode), meta.backend).Inc()
+		}
+	}
+
 	if resp.Header.Get("Content-Type") == "text/event-stream" {
 		logger.Printf("流式响应开始: %d", resp.StatusCode)
 		return nil
@@ -262,7 +536,55 @@ func logResponse(resp *http.Response) error {
 	return nil
 }
 
-func handleNonStreamRequest(c *gin.Context, proxy *httputil.ReverseProxy) {
+// makeModifyResponse 包装 logResponse，额外把非流式响应从 Backend 自己的协议转换回 OpenAI 格式。
+// 流式响应的转换在 streamResponseWriter 里逐块进行。
+func makeModifyResponse(translator Translator) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		if err := logResponse(resp); err != nil {
+			return err
+		}
+
+		if _, isPassthrough := translator.(passthroughTranslator); translator == nil || isPassthrough {
+			return nil
+		}
+		if resp.Header.Get("Content-Type") == "text/event-stream" {
+			return nil
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		translated, err := translator.TranslateResponseBody(body)
+		if err != nil {
+			logger.Printf("转换响应体时出错: %v", err)
+			translated = body
+		}
+		resp.Body = io.NopCloser(bytes.NewBuffer(translated))
+		resp.ContentLength = int64(len(translated))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(translated)))
+		return nil
+	}
+}
+
+func handleNonStreamRequest(c *gin.Context, proxy *httputil.ReverseProxy, meta requestMeta) {
+	cacheKey, cacheable := "", false
+	if respCache != nil && !config.Cache.disabledFor(meta.model) {
+		cacheKey, cacheable = cacheKeyForRequest(meta.body, config.Cache.temperatureThreshold())
+	}
+
+	if cacheable {
+		if cached, ok := respCache.Get(c.Request.Context(), cacheKey); ok {
+			logger.Printf("命中响应缓存: model=%s key=%s", meta.model, cacheKey)
+			c.Header("X-Proxy-Cache", "HIT")
+			observeRequest(meta, http.StatusOK)
+			c.Data(http.StatusOK, "application/json", cached)
+			return
+		}
+		c.Header("X-Proxy-Cache", "MISS")
+	}
+
 	responseWriter := &responseBodyWriter{
 		ResponseWriter: c.Writer,
 		body:           &bytes.Buffer{},
@@ -273,6 +595,10 @@ func handleNonStreamRequest(c *gin.Context, proxy *httputil.ReverseProxy) {
 	body := responseWriter.body.Bytes()
 	logger.Printf("响应: %d\n响应体: %s", responseWriter.statusCode, string(body))
 
+	if cacheable && responseWriter.statusCode == http.StatusOK && len(body) <= config.Cache.maxEntryBytes() {
+		respCache.Set(c.Request.Context(), cacheKey, body, config.Cache.ttl())
+	}
+
 	// 解析响应体以提取AI的回复
 	var response map[string]interface{}
 	err := json.Unmarshal(body, &response)
@@ -290,6 +616,47 @@ func handleNonStreamRequest(c *gin.Context, proxy *httputil.ReverseProxy) {
 		logger.Printf("解析AI回复时出错: %v", err)
 	}
 
+	promptTokens, completionTokens := extractUsage(response)
+	observeRequest(meta, responseWriter.statusCode)
+	observeTokens(meta.model, promptTokens, completionTokens)
+
+	if reqStore != nil {
+		errMsg := ""
+		if responseWriter.statusCode >= http.StatusBadRequest {
+			errMsg = string(body)
+		}
+		if _, insertErr := reqStore.Insert(store.Record{
+			Method:           meta.method,
+			Path:             meta.path,
+			Model:            meta.model,
+			Backend:          meta.backend,
+			StatusCode:       responseWriter.statusCode,
+			LatencyMS:        time.Since(meta.start).Milliseconds(),
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			RequestBody:      string(meta.body),
+			ResponseBody:     string(body),
+			Error:            errMsg,
+		}); insertErr != nil {
+			logger.Printf("写入请求存储时出错: %v", insertErr)
+		}
+	}
+
 	// 依赖于CORS中间件设置CORS头，无需在这里手动设置
 	c.Data(responseWriter.statusCode, responseWriter.Header().Get("Content-Type"), body)
 }
+
+// extractUsage 从已解析的响应体中取出 usage.prompt_tokens / usage.completion_tokens。
+func extractUsage(response map[string]interface{}) (promptTokens, completionTokens int) {
+	usage, ok := response["usage"].(map[string]interface{})
+	if !ok {
+		return 0, 0
+	}
+	if v, ok := usage["prompt_tokens"].(float64); ok {
+		promptTokens = int(v)
+	}
+	if v, ok := usage["completion_tokens"].(float64); ok {
+		completionTokens = int(v)
+	}
+	return promptTokens, completionTokens
+}
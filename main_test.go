@@ -0,0 +1,15 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+// TestMain 为包内所有测试准备一个丢弃输出的 logger，
+// 因为 init() 在测试二进制下会跳过真正的 config.json 加载（不会设置 logger）。
+func TestMain(m *testing.M) {
+	logger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "openai_proxy_requests_total",
+		Help: "代理处理过的请求总数",
+	}, []string{"method", "path", "model", "status", "backend"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "openai_proxy_request_duration_seconds",
+		Help:    "请求从进入代理到响应完成的耗时",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path", "model", "backend"})
+
+	upstreamErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "openai_proxy_upstream_errors_total",
+		Help: "上游返回 4xx/5xx 的次数",
+	}, []string{"method", "path", "model", "status", "backend"})
+
+	tokensTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "openai_proxy_tokens_total",
+		Help: "从 usage 字段解析出的 token 用量",
+	}, []string{"model", "type"})
+
+	streamChunksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "openai_proxy_stream_chunks_total",
+		Help: "转发给客户端的 SSE 数据块总数",
+	}, []string{"model", "backend"})
+
+	activeStreams = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "openai_proxy_active_streams",
+		Help: "当前打开的流式连接数",
+	}, []string{"model", "backend"})
+)
+
+func init() {
+	prometheus.MustRegister(requestsTotal, requestDuration, upstreamErrorsTotal, tokensTotal, streamChunksTotal, activeStreams)
+}
+
+// metricsHandler 包装 promhttp.Handler 供 gin 路由使用。
+func metricsHandler() gin.HandlerFunc {
+	h := promhttp.Handler()
+	return func(c *gin.Context) {
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// observeRequest 记录一次请求完成后的计数器和耗时直方图。
+// 上游错误单独在 logResponse 里按上游返回的最终响应计数，避免和这里重复统计；
+// keyPoolTransport 换 Key 重试掉的中间响应和没有拿到响应的网络错误不计入。
+func observeRequest(meta requestMeta, statusCode int) {
+	status := strconv.Itoa(statusCode)
+	requestsTotal.WithLabelValues(meta.method, meta.path, meta.model, status, meta.backend).Inc()
+	requestDuration.WithLabelValues(meta.method, meta.path, meta.model, meta.backend).Observe(time.Since(meta.start).Seconds())
+}
+
+// observeTokens 把一次非流式响应里解析出的 token 用量计入 tokensTotal。
+func observeTokens(model string, promptTokens, completionTokens int) {
+	if promptTokens > 0 {
+		tokensTotal.WithLabelValues(model, "prompt").Add(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		tokensTotal.WithLabelValues(model, "completion").Add(float64(completionTokens))
+	}
+}
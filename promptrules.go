@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// PromptRuleAction 决定规则命中后如何处理消息内容。
+type PromptRuleAction string
+
+const (
+	ActionReplace      PromptRuleAction = "replace"
+	ActionPrepend      PromptRuleAction = "prepend"
+	ActionAppend       PromptRuleAction = "append"
+	ActionInjectSystem PromptRuleAction = "inject_system"
+	ActionDropMessage  PromptRuleAction = "drop_message"
+)
+
+// PromptRule 是配置文件 "prompt_rules" 数组里的一项。
+// match 既可以是字面量也可以是正则（regex=true 时），replace 支持正则捕获组（$1）
+// 以及以请求元数据（Model/Role/Content）为上下文的 text/template 语法。
+type PromptRule struct {
+	Match   string           `json:"match"`
+	Regex   bool             `json:"regex"`
+	Replace string           `json:"replace"`
+	Roles   []string         `json:"roles"`
+	Models  []string         `json:"models"`
+	Action  PromptRuleAction `json:"action"`
+
+	compiled *regexp.Regexp
+	tmpl     *template.Template
+}
+
+// promptRuleContext 是渲染 Replace 模板时可用的请求元数据。
+type promptRuleContext struct {
+	Model   string
+	Role    string
+	Content string
+}
+
+// compilePromptRules 预编译配置中的规则（正则、模板），规则按配置顺序依次生效。
+func compilePromptRules(defs []PromptRule) []*PromptRule {
+	rules := make([]*PromptRule, 0, len(defs))
+	for _, def := range defs {
+		rule := def
+
+		if rule.Regex && rule.Match != "" {
+			compiled, err := regexp.Compile(rule.Match)
+			if err != nil {
+				logger.Printf("编译提示词规则正则 %q 时出错: %v", rule.Match, err)
+			} else {
+				rule.compiled = compiled
+			}
+		}
+
+		if rule.Replace != "" {
+			tmpl, err := template.New("prompt-rule").Parse(rule.Replace)
+			if err != nil {
+				logger.Printf("解析提示词规则模板 %q 时出错: %v", rule.Replace, err)
+			} else {
+				rule.tmpl = tmpl
+			}
+		}
+
+		if rule.Action == "" {
+			rule.Action = ActionReplace
+		}
+
+		rules = append(rules, &rule)
+	}
+	return rules
+}
+
+// evaluatePromptRules 依次对 messages 应用每条规则，返回处理后的消息列表。
+// dryRun 为 true 时只记录将会发生的改动，不真正修改消息内容。
+func evaluatePromptRules(rules []*PromptRule, model string, messages []interface{}, dryRun bool) []interface{} {
+	var injected []interface{}
+	// injectedRules 记录本次请求里每条 inject_system 规则是否已经注入过，
+	// 避免同一条规则匹配多条消息时重复注入同一段系统提示。
+	injectedRules := make(map[*PromptRule]bool)
+	result := make([]interface{}, 0, len(messages))
+
+	for _, msg := range messages {
+		message, ok := msg.(map[string]interface{})
+		if !ok {
+			result = append(result, msg)
+			continue
+		}
+		content, ok := message["content"].(string)
+		if !ok {
+			result = append(result, msg)
+			continue
+		}
+		role, _ := message["role"].(string)
+		original := content
+		dropped := false
+
+		for _, rule := range rules {
+			if !rule.appliesTo(model, role) {
+				continue
+			}
+			ctx := promptRuleContext{Model: model, Role: role, Content: content}
+
+			switch rule.Action {
+			case ActionDropMessage:
+				if rule.Match == "" || rule.matches(content) {
+					dropped = true
+				}
+			case ActionPrepend:
+				if rule.Match == "" || rule.matches(content) {
+					content = rule.renderReplace(ctx) + content
+				}
+			case ActionAppend:
+				if rule.Match == "" || rule.matches(content) {
+					content = content + rule.renderReplace(ctx)
+				}
+			case ActionInjectSystem:
+				if (rule.Match == "" || rule.matches(content)) && !injectedRules[rule] {
+					injectedRules[rule] = true
+					injected = append(injected, map[string]interface{}{
+						"role":    "system",
+						"content": rule.renderReplace(ctx),
+					})
+				}
+			default: // ActionReplace
+				if newContent, matched := rule.substitute(ctx); matched {
+					content = newContent
+				}
+			}
+
+			if dropped {
+				break
+			}
+		}
+
+		if dropped {
+			logPromptChange(dryRun, "丢弃消息", role, original, "")
+			if !dryRun {
+				continue
+			}
+			// dry-run 模式下只记录会被丢弃的消息，不真正从出站请求体里移除。
+			result = append(result, message)
+			continue
+		}
+
+		if content != original {
+			logPromptChange(dryRun, "修改提示词", role, original, content)
+		}
+
+		if !dryRun {
+			message["content"] = content
+		}
+		result = append(result, message)
+	}
+
+	if len(injected) > 0 && !dryRun {
+		result = append(injected, result...)
+	}
+
+	return result
+}
+
+func logPromptChange(dryRun bool, action, role, before, after string) {
+	prefix := ""
+	if dryRun {
+		prefix = "[dry-run] "
+	}
+	if after == "" {
+		logger.Printf("%s%s: role=%s content=%s", prefix, action, role, before)
+		return
+	}
+	logger.Printf("%s%s: role=%s\n原始提示词: %s\n修改后的提示词: %s", prefix, action, role, before, after)
+}
+
+// appliesTo 判断规则是否对给定的 model/role 生效（Roles/Models 为空表示不限制）。
+func (r *PromptRule) appliesTo(model, role string) bool {
+	if len(r.Roles) > 0 && !containsString(r.Roles, role) {
+		return false
+	}
+	if len(r.Models) > 0 && !containsString(r.Models, model) {
+		return false
+	}
+	return true
+}
+
+// matches 判断 content 是否命中规则的 match。
+func (r *PromptRule) matches(content string) bool {
+	if r.compiled != nil {
+		return r.compiled.MatchString(content)
+	}
+	return strings.Contains(content, r.Match)
+}
+
+// substitute 对 content 执行 replace 动作的文本替换，支持正则捕获组和模板渲染。
+func (r *PromptRule) substitute(ctx promptRuleContext) (string, bool) {
+	if r.Match == "" {
+		return ctx.Content, false
+	}
+
+	replacement := r.renderReplace(ctx)
+
+	if r.compiled != nil {
+		if !r.compiled.MatchString(ctx.Content) {
+			return ctx.Content, false
+		}
+		return r.compiled.ReplaceAllString(ctx.Content, replacement), true
+	}
+
+	if !strings.Contains(ctx.Content, r.Match) {
+		return ctx.Content, false
+	}
+	return strings.ReplaceAll(ctx.Content, r.Match, replacement), true
+}
+
+// renderReplace 用请求元数据渲染 Replace 模板；没有模板语法时原样返回。
+func (r *PromptRule) renderReplace(ctx promptRuleContext) string {
+	if r.tmpl == nil {
+		return r.Replace
+	}
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, ctx); err != nil {
+		logger.Printf("渲染提示词规则模板时出错: %v", err)
+		return r.Replace
+	}
+	return buf.String()
+}
+
+func containsString(list []string, want string) bool {
+	for _, v := range list {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package main
+
+import "testing"
+
+func messagesOf(pairs ...string) []interface{} {
+	messages := make([]interface{}, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		messages = append(messages, map[string]interface{}{
+			"role":    pairs[i],
+			"content": pairs[i+1],
+		})
+	}
+	return messages
+}
+
+func contentOf(t *testing.T, msg interface{}) string {
+	t.Helper()
+	m, ok := msg.(map[string]interface{})
+	if !ok {
+		t.Fatalf("消息不是 map[string]interface{}: %#v", msg)
+	}
+	content, _ := m["content"].(string)
+	return content
+}
+
+func TestEvaluatePromptRulesReplace(t *testing.T) {
+	rules := compilePromptRules([]PromptRule{
+		{Match: "foo", Replace: "bar", Action: ActionReplace},
+	})
+
+	result := evaluatePromptRules(rules, "gpt-4", messagesOf("user", "say foo now"), false)
+	if got := contentOf(t, result[0]); got != "say bar now" {
+		t.Fatalf("替换后内容 = %q, 期望 %q", got, "say bar now")
+	}
+}
+
+func TestEvaluatePromptRulesPrependAppend(t *testing.T) {
+	rules := compilePromptRules([]PromptRule{
+		{Action: ActionPrepend, Replace: "前缀-"},
+		{Action: ActionAppend, Replace: "-后缀"},
+	})
+
+	result := evaluatePromptRules(rules, "gpt-4", messagesOf("user", "中间"), false)
+	if got := contentOf(t, result[0]); got != "前缀-中间-后缀" {
+		t.Fatalf("内容 = %q, 期望 %q", got, "前缀-中间-后缀")
+	}
+}
+
+func TestEvaluatePromptRulesDropMessage(t *testing.T) {
+	rules := compilePromptRules([]PromptRule{
+		{Match: "secret", Action: ActionDropMessage},
+	})
+
+	result := evaluatePromptRules(rules, "gpt-4", messagesOf(
+		"user", "this has a secret in it",
+		"user", "this one is fine",
+	), false)
+
+	if len(result) != 1 {
+		t.Fatalf("丢弃后消息数 = %d, 期望 1", len(result))
+	}
+	if got := contentOf(t, result[0]); got != "this one is fine" {
+		t.Fatalf("剩余消息内容 = %q, 期望保留第二条", got)
+	}
+}
+
+func TestEvaluatePromptRulesInjectSystem(t *testing.T) {
+	rules := compilePromptRules([]PromptRule{
+		{Action: ActionInjectSystem, Replace: "系统提示"},
+	})
+
+	result := evaluatePromptRules(rules, "gpt-4", messagesOf("user", "hi"), false)
+	if len(result) != 2 {
+		t.Fatalf("注入 system 消息后总数 = %d, 期望 2", len(result))
+	}
+	injected, ok := result[0].(map[string]interface{})
+	if !ok || injected["role"] != "system" || injected["content"] != "系统提示" {
+		t.Fatalf("注入的消息不符合预期: %#v", result[0])
+	}
+}
+
+func TestEvaluatePromptRulesScopedByModelAndRole(t *testing.T) {
+	rules := compilePromptRules([]PromptRule{
+		{Match: "foo", Replace: "bar", Action: ActionReplace, Models: []string{"claude-"}, Roles: []string{"system"}},
+	})
+
+	result := evaluatePromptRules(rules, "gpt-4", messagesOf("user", "foo"), false)
+	if got := contentOf(t, result[0]); got != "foo" {
+		t.Fatalf("不匹配 model/role 的规则不应生效，内容 = %q", got)
+	}
+}
+
+func TestEvaluatePromptRulesDryRunDoesNotMutate(t *testing.T) {
+	rules := compilePromptRules([]PromptRule{
+		{Match: "foo", Replace: "bar", Action: ActionReplace},
+	})
+
+	original := messagesOf("user", "foo")
+	result := evaluatePromptRules(rules, "gpt-4", original, true)
+	if got := contentOf(t, result[0]); got != "foo" {
+		t.Fatalf("dry-run 模式下不应修改消息内容，得到 %q", got)
+	}
+}
+
+func TestEvaluatePromptRulesDryRunDoesNotDropMessage(t *testing.T) {
+	rules := compilePromptRules([]PromptRule{
+		{Match: "secret", Action: ActionDropMessage},
+	})
+
+	result := evaluatePromptRules(rules, "gpt-4", messagesOf(
+		"user", "this has a secret in it",
+		"user", "this one is fine",
+	), true)
+
+	if len(result) != 2 {
+		t.Fatalf("dry-run 模式下不应真正丢弃消息，消息数 = %d, 期望 2", len(result))
+	}
+	if got := contentOf(t, result[0]); got != "this has a secret in it" {
+		t.Fatalf("dry-run 模式下应保留原始消息内容，得到 %q", got)
+	}
+}
+
+func TestEvaluatePromptRulesInjectSystemOncePerRequest(t *testing.T) {
+	rules := compilePromptRules([]PromptRule{
+		{Match: "trigger", Action: ActionInjectSystem, Replace: "系统提示"},
+	})
+
+	result := evaluatePromptRules(rules, "gpt-4", messagesOf(
+		"user", "trigger one",
+		"user", "trigger two",
+	), false)
+
+	injectedCount := 0
+	for _, msg := range result {
+		m, ok := msg.(map[string]interface{})
+		if ok && m["role"] == "system" {
+			injectedCount++
+		}
+	}
+	if injectedCount != 1 {
+		t.Fatalf("同一条规则匹配多条消息时应只注入一次系统提示，得到 %d 次", injectedCount)
+	}
+	if len(result) != 3 {
+		t.Fatalf("消息总数 = %d, 期望 3（1 条注入的 system + 2 条原始消息）", len(result))
+	}
+}
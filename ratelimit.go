@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultRequestsPerSecond    = 5.0
+	defaultMaxConcurrentStreams = 4
+)
+
+// RateLimitConfig 是单个客户端（或客户端+model 组合）的限流参数。
+type RateLimitConfig struct {
+	RequestsPerSecond    float64 `json:"requests_per_second"`
+	Burst                int     `json:"burst"`
+	MaxConcurrentStreams int     `json:"max_concurrent_streams"`
+}
+
+// RateLimitingConfig 是配置文件中的 "rate_limiting" 节点，PerModel 可以覆盖指定 model 的限流参数。
+type RateLimitingConfig struct {
+	RateLimitConfig
+	PerModel map[string]RateLimitConfig `json:"per_model"`
+}
+
+// clientState 是某个客户端针对某个 model 的限流状态：一个令牌桶加一个限制并发流数量的信号量。
+type clientState struct {
+	limiter   *rate.Limiter
+	streamSem chan struct{}
+}
+
+// RateLimiter 按 "客户端标识 + model" 维护独立的限流状态，所有方法并发安全。
+type RateLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*clientState
+	cfg     RateLimitingConfig
+}
+
+// NewRateLimiter 根据配置创建 RateLimiter。
+func NewRateLimiter(cfg RateLimitingConfig) *RateLimiter {
+	return &RateLimiter{clients: make(map[string]*clientState), cfg: cfg}
+}
+
+// limitsFor 返回某个 model 应该使用的限流参数，没有针对该 model 的配置时回退到默认参数。
+func (rl *RateLimiter) limitsFor(model string) RateLimitConfig {
+	if limits, ok := rl.cfg.PerModel[model]; ok {
+		return limits
+	}
+	return rl.cfg.RateLimitConfig
+}
+
+// stateFor 返回（必要时创建）指定客户端在指定 model 下的限流状态。
+func (rl *RateLimiter) stateFor(clientKey, model string) (*clientState, RateLimitConfig) {
+	limits := rl.limitsFor(model)
+
+	rps := limits.RequestsPerSecond
+	if rps <= 0 {
+		rps = defaultRequestsPerSecond
+	}
+	burst := limits.Burst
+	if burst <= 0 {
+		burst = int(rps)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	maxStreams := limits.MaxConcurrentStreams
+	if maxStreams <= 0 {
+		maxStreams = defaultMaxConcurrentStreams
+	}
+	limits.RequestsPerSecond = rps
+	limits.Burst = burst
+	limits.MaxConcurrentStreams = maxStreams
+
+	key := clientKey + "|" + model
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if state, ok := rl.clients[key]; ok {
+		return state, limits
+	}
+	state := &clientState{
+		limiter:   rate.NewLimiter(rate.Limit(rps), burst),
+		streamSem: make(chan struct{}, maxStreams),
+	}
+	rl.clients[key] = state
+	return state, limits
+}
+
+// clientIdentity 把客户端 IP 和 API Key 的哈希组合成限流用的客户端标识，不在日志/响应里泄露完整 Key。
+func clientIdentity(c *gin.Context) string {
+	ip := c.ClientIP()
+	apiKey := c.GetHeader("Authorization")
+	hash := sha256.Sum256([]byte(apiKey))
+	return ip + ":" + hex.EncodeToString(hash[:8])
+}
+
+// rateLimitMiddleware 在进入 handleProxy 之前按客户端 + model 做请求速率限制和并发流连接数限制。
+func rateLimitMiddleware(rl *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		var bodyBytes []byte
+		if c.Request.Body != nil {
+			bodyBytes, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+		}
+		model := extractModel(bodyBytes)
+
+		clientKey := clientIdentity(c)
+		state, limits := rl.stateFor(clientKey, model)
+
+		reservation := state.limiter.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			c.Header("Retry-After", strconv.Itoa(int(delay.Seconds())+1))
+			writeRateLimitHeaders(c, state, limits)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "请求过于频繁，请稍后再试"})
+			return
+		}
+		writeRateLimitHeaders(c, state, limits)
+
+		isStreamRequest := strings.Contains(c.Request.URL.Path, "/stream") || c.Request.Header.Get("Accept") == "text/event-stream"
+		if isStreamRequest {
+			select {
+			case state.streamSem <- struct{}{}:
+				defer func() { <-state.streamSem }()
+			default:
+				c.Header("Retry-After", "5")
+				writeRateLimitHeaders(c, state, limits)
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "并发流式连接数已达上限"})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func writeRateLimitHeaders(c *gin.Context, state *clientState, limits RateLimitConfig) {
+	remaining := int(state.limiter.Tokens())
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limits.Burst))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+}
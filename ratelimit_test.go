@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWriteRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	rl := NewRateLimiter(RateLimitingConfig{RateLimitConfig: RateLimitConfig{RequestsPerSecond: 5, Burst: 5}})
+	state, limits := rl.stateFor("client", "gpt-4")
+
+	writeRateLimitHeaders(c, state, limits)
+
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "5" {
+		t.Fatalf("X-RateLimit-Limit = %q, 期望 %q", got, "5")
+	}
+	if w.Header().Get("X-RateLimit-Remaining") == "" {
+		t.Fatal("应该设置 X-RateLimit-Remaining")
+	}
+}
+
+func TestRateLimitMiddlewareRequestRateExceeded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rl := NewRateLimiter(RateLimitingConfig{RateLimitConfig: RateLimitConfig{RequestsPerSecond: 1, Burst: 1}})
+	r.Any("/*path", rateLimitMiddleware(rl), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req1 := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model":"gpt-4"}`))
+	w1 := httptest.NewRecorder()
+	r.ServeHTTP(w1, req1)
+	if w1.Code != http.StatusOK {
+		t.Fatalf("突发容量内的第一个请求应该放行，状态码 = %d", w1.Code)
+	}
+
+	req2 := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(`{"model":"gpt-4"}`))
+	w2 := httptest.NewRecorder()
+	r.ServeHTTP(w2, req2)
+	if w2.Code != http.StatusTooManyRequests {
+		t.Fatalf("超出突发容量后应该返回 429，状态码 = %d", w2.Code)
+	}
+	if w2.Header().Get("Retry-After") == "" {
+		t.Error("429 响应应该带 Retry-After")
+	}
+	if w2.Header().Get("X-RateLimit-Limit") == "" {
+		t.Error("429 响应应该带 X-RateLimit-Limit")
+	}
+}
+
+func TestRateLimitMiddlewareConcurrentStreamLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rl := NewRateLimiter(RateLimitingConfig{RateLimitConfig: RateLimitConfig{RequestsPerSecond: 1000, Burst: 1000, MaxConcurrentStreams: 1}})
+
+	r := gin.New()
+	r.Any("/*path", rateLimitMiddleware(rl), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/stream", nil)
+
+	// 提前占满该客户端+model 的并发流信号量，模拟已有一个流式连接在处理中。
+	probe, _ := gin.CreateTestContext(httptest.NewRecorder())
+	probe.Request = req
+	clientKey := clientIdentity(probe)
+	state, _ := rl.stateFor(clientKey, "")
+	state.streamSem <- struct{}{}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("并发流连接数超限应该返回 429，状态码 = %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("并发流超限的 429 响应也应该带 Retry-After")
+	}
+	if w.Header().Get("X-RateLimit-Limit") == "" {
+		t.Error("并发流超限的 429 响应也应该带 X-RateLimit-Limit")
+	}
+}
@@ -0,0 +1,225 @@
+// Package store 把每次请求/响应持久化到 SQLite，供 /admin 系列接口查询。
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Record 是一次请求/响应往来的完整记录。
+type Record struct {
+	ID               int64     `json:"id"`
+	Method           string    `json:"method"`
+	Path             string    `json:"path"`
+	Model            string    `json:"model"`
+	Backend          string    `json:"backend"`
+	StatusCode       int       `json:"status_code"`
+	LatencyMS        int64     `json:"latency_ms"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	RequestBody      string    `json:"request_body"`
+	ResponseBody     string    `json:"response_body"`
+	StreamChunkCount int       `json:"stream_chunk_count"`
+	Error            string    `json:"error,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Store 是对底层 SQLite 数据库的薄封装。
+type Store struct {
+	db *sql.DB
+}
+
+// Open 打开（必要时创建）path 指向的 SQLite 数据库，并确保表结构存在。
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) migrate() error {
+	_, err := s.db.Exec(`
+CREATE TABLE IF NOT EXISTS requests (
+	id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+	method             TEXT NOT NULL,
+	path               TEXT NOT NULL,
+	model              TEXT,
+	backend            TEXT,
+	status_code        INTEGER,
+	latency_ms         INTEGER,
+	prompt_tokens      INTEGER,
+	completion_tokens  INTEGER,
+	request_body       TEXT,
+	response_body      TEXT,
+	stream_chunk_count INTEGER,
+	error              TEXT,
+	created_at         DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_requests_model ON requests(model);
+CREATE INDEX IF NOT EXISTS idx_requests_created_at ON requests(created_at);
+`)
+	return err
+}
+
+// sqliteTimeFormat 是写入/比较 created_at 列时使用的时间格式。
+// SQLite 的 date()/strftime() 只认识 "YYYY-MM-DD HH:MM:SS" 这类格式，
+// 不认识 Go 默认 RFC3339Nano 格式里的 "T" 和时区偏移，所以存库前要显式转换。
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+func formatSQLiteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+// Insert 持久化一条记录，返回自增主键。CreatedAt 为空时使用当前时间。
+func (s *Store) Insert(r Record) (int64, error) {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+
+	res, err := s.db.Exec(`
+INSERT INTO requests
+	(method, path, model, backend, status_code, latency_ms, prompt_tokens, completion_tokens, request_body, response_body, stream_chunk_count, error, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Method, r.Path, r.Model, r.Backend, r.StatusCode, r.LatencyMS,
+		r.PromptTokens, r.CompletionTokens, r.RequestBody, r.ResponseBody,
+		r.StreamChunkCount, r.Error, formatSQLiteTime(r.CreatedAt))
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Filter 筛选 List 返回的记录，零值字段表示不限制。
+type Filter struct {
+	Model      string
+	StatusCode int
+	Since      time.Time
+	Until      time.Time
+}
+
+// List 按 Filter 查询记录，按 id 倒序（最近插入的在前）返回最多 limit 条。
+func (s *Store) List(f Filter, limit int) ([]Record, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var clauses []string
+	var args []interface{}
+
+	if f.Model != "" {
+		clauses = append(clauses, "model = ?")
+		args = append(args, f.Model)
+	}
+	if f.StatusCode != 0 {
+		clauses = append(clauses, "status_code = ?")
+		args = append(args, f.StatusCode)
+	}
+	if !f.Since.IsZero() {
+		clauses = append(clauses, "created_at >= ?")
+		args = append(args, formatSQLiteTime(f.Since))
+	}
+	if !f.Until.IsZero() {
+		clauses = append(clauses, "created_at <= ?")
+		args = append(args, formatSQLiteTime(f.Until))
+	}
+
+	query := "SELECT id, method, path, model, backend, status_code, latency_ms, prompt_tokens, completion_tokens, request_body, response_body, stream_chunk_count, error, created_at FROM requests"
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var r Record
+		if err := rows.Scan(&r.ID, &r.Method, &r.Path, &r.Model, &r.Backend, &r.StatusCode,
+			&r.LatencyMS, &r.PromptTokens, &r.CompletionTokens, &r.RequestBody, &r.ResponseBody,
+			&r.StreamChunkCount, &r.Error, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// Get 按主键查询单条记录，不存在时返回 (nil, nil)。
+func (s *Store) Get(id int64) (*Record, error) {
+	var r Record
+	err := s.db.QueryRow(`
+SELECT id, method, path, model, backend, status_code, latency_ms, prompt_tokens, completion_tokens, request_body, response_body, stream_chunk_count, error, created_at
+FROM requests WHERE id = ?`, id).Scan(
+		&r.ID, &r.Method, &r.Path, &r.Model, &r.Backend, &r.StatusCode,
+		&r.LatencyMS, &r.PromptTokens, &r.CompletionTokens, &r.RequestBody, &r.ResponseBody,
+		&r.StreamChunkCount, &r.Error, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// DailyStat 是按天、按 model 聚合后的统计结果。
+type DailyStat struct {
+	Date             string `json:"date"`
+	Model            string `json:"model"`
+	RequestCount     int    `json:"request_count"`
+	ErrorCount       int    `json:"error_count"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// Stats 按天、按 model 聚合 since 之后的 token 用量和错误率。
+func (s *Store) Stats(since time.Time) ([]DailyStat, error) {
+	rows, err := s.db.Query(`
+SELECT date(created_at) AS day, model,
+	COUNT(*) AS request_count,
+	SUM(CASE WHEN status_code >= 400 OR status_code = 0 THEN 1 ELSE 0 END) AS error_count,
+	SUM(prompt_tokens) AS prompt_tokens,
+	SUM(completion_tokens) AS completion_tokens
+FROM requests
+WHERE created_at >= ?
+GROUP BY day, model
+ORDER BY day DESC`, formatSQLiteTime(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []DailyStat
+	for rows.Next() {
+		var st DailyStat
+		if err := rows.Scan(&st.Date, &st.Model, &st.RequestCount, &st.ErrorCount, &st.PromptTokens, &st.CompletionTokens); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+// Close 关闭底层数据库连接。
+func (s *Store) Close() error {
+	return s.db.Close()
+}
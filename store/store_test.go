@@ -0,0 +1,180 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open 出错: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStoreListFiltersByModelAndStatus(t *testing.T) {
+	s := openTestStore(t)
+
+	now := time.Now()
+	records := []Record{
+		{Method: "POST", Path: "/v1/chat/completions", Model: "gpt-4", StatusCode: 200, CreatedAt: now},
+		{Method: "POST", Path: "/v1/chat/completions", Model: "gpt-4", StatusCode: 500, CreatedAt: now},
+		{Method: "POST", Path: "/v1/chat/completions", Model: "claude-3", StatusCode: 200, CreatedAt: now},
+	}
+	for _, r := range records {
+		if _, err := s.Insert(r); err != nil {
+			t.Fatalf("Insert 出错: %v", err)
+		}
+	}
+
+	got, err := s.List(Filter{Model: "gpt-4"}, 0)
+	if err != nil {
+		t.Fatalf("List 出错: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("按 model 筛选后记录数 = %d, 期望 2", len(got))
+	}
+
+	got, err = s.List(Filter{Model: "gpt-4", StatusCode: 500}, 0)
+	if err != nil {
+		t.Fatalf("List 出错: %v", err)
+	}
+	if len(got) != 1 || got[0].StatusCode != 500 {
+		t.Fatalf("按 model + status_code 筛选后记录不符合预期: %#v", got)
+	}
+
+	got, err = s.List(Filter{Model: "不存在的model"}, 0)
+	if err != nil {
+		t.Fatalf("List 出错: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("不存在的 model 应该返回空结果，得到 %d 条", len(got))
+	}
+}
+
+func TestStoreListOrdersByIDDescAndRespectsLimit(t *testing.T) {
+	s := openTestStore(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.Insert(Record{Method: "GET", Path: "/v1/models", CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Insert 出错: %v", err)
+		}
+	}
+
+	got, err := s.List(Filter{}, 2)
+	if err != nil {
+		t.Fatalf("List 出错: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("limit=2 时应该只返回 2 条，得到 %d", len(got))
+	}
+	if got[0].ID < got[1].ID {
+		t.Fatalf("应按 id 倒序返回，got[0].ID=%d got[1].ID=%d", got[0].ID, got[1].ID)
+	}
+}
+
+func TestStoreListFiltersBySinceAndUntil(t *testing.T) {
+	s := openTestStore(t)
+
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now()
+
+	if _, err := s.Insert(Record{Method: "POST", Path: "/v1/chat/completions", CreatedAt: old}); err != nil {
+		t.Fatalf("Insert 出错: %v", err)
+	}
+	if _, err := s.Insert(Record{Method: "POST", Path: "/v1/chat/completions", CreatedAt: recent}); err != nil {
+		t.Fatalf("Insert 出错: %v", err)
+	}
+
+	got, err := s.List(Filter{Since: time.Now().Add(-1 * time.Hour)}, 0)
+	if err != nil {
+		t.Fatalf("List 出错: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Since 筛选后应该只剩最近那条记录，得到 %d 条", len(got))
+	}
+
+	got, err = s.List(Filter{Until: time.Now().Add(-1 * time.Hour)}, 0)
+	if err != nil {
+		t.Fatalf("List 出错: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Until 筛选后应该只剩较早那条记录，得到 %d 条", len(got))
+	}
+}
+
+func TestStoreStatsAggregatesPerDayAndModel(t *testing.T) {
+	s := openTestStore(t)
+
+	now := time.Now()
+	records := []Record{
+		{Model: "gpt-4", StatusCode: 200, PromptTokens: 10, CompletionTokens: 5, CreatedAt: now},
+		{Model: "gpt-4", StatusCode: 500, PromptTokens: 20, CompletionTokens: 0, CreatedAt: now},
+		{Model: "claude-3", StatusCode: 200, PromptTokens: 7, CompletionTokens: 3, CreatedAt: now},
+	}
+	for _, r := range records {
+		if _, err := s.Insert(r); err != nil {
+			t.Fatalf("Insert 出错: %v", err)
+		}
+	}
+
+	stats, err := s.Stats(now.Add(-1 * time.Hour))
+	if err != nil {
+		t.Fatalf("Stats 出错: %v", err)
+	}
+
+	byModel := make(map[string]DailyStat)
+	for _, st := range stats {
+		byModel[st.Model] = st
+	}
+
+	gpt4, ok := byModel["gpt-4"]
+	if !ok {
+		t.Fatalf("缺少 gpt-4 的统计结果: %#v", stats)
+	}
+	if gpt4.RequestCount != 2 || gpt4.ErrorCount != 1 || gpt4.PromptTokens != 30 || gpt4.CompletionTokens != 5 {
+		t.Fatalf("gpt-4 的统计结果不符合预期: %#v", gpt4)
+	}
+
+	claude, ok := byModel["claude-3"]
+	if !ok {
+		t.Fatalf("缺少 claude-3 的统计结果: %#v", stats)
+	}
+	if claude.RequestCount != 1 || claude.ErrorCount != 0 {
+		t.Fatalf("claude-3 的统计结果不符合预期: %#v", claude)
+	}
+}
+
+func TestStoreStatsExcludesRecordsBeforeSince(t *testing.T) {
+	s := openTestStore(t)
+
+	old := time.Now().Add(-72 * time.Hour)
+	if _, err := s.Insert(Record{Model: "gpt-4", StatusCode: 200, CreatedAt: old}); err != nil {
+		t.Fatalf("Insert 出错: %v", err)
+	}
+
+	stats, err := s.Stats(time.Now().Add(-1 * time.Hour))
+	if err != nil {
+		t.Fatalf("Stats 出错: %v", err)
+	}
+	if len(stats) != 0 {
+		t.Fatalf("since 之前的记录不应该出现在统计结果里，得到 %#v", stats)
+	}
+}
+
+func TestStoreGetReturnsNilForMissingRecord(t *testing.T) {
+	s := openTestStore(t)
+
+	got, err := s.Get(999)
+	if err != nil {
+		t.Fatalf("Get 出错: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("不存在的记录应该返回 nil, 得到 %#v", got)
+	}
+}
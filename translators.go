@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// streamDone 是 TranslateStreamChunk 在遇到上游流结束标志时返回的哨兵值，
+// streamResponseWriter 看到它会向客户端写出标准的 "data: [DONE]"。
+var streamDone = []byte("[DONE]")
+
+// Translator 把 OpenAI chat-completions 协议的请求/响应与某个上游自己的协议互相转换，
+// 使得客户端始终只需要说 OpenAI 的协议。
+type Translator interface {
+	// TranslateRequestBody 把 OpenAI 格式的请求体转换成该上游自己的请求格式。
+	TranslateRequestBody(body []byte) ([]byte, error)
+	// TranslateResponseBody 把该上游的非流式响应体转换回 OpenAI 格式。
+	TranslateResponseBody(body []byte) ([]byte, error)
+	// TranslateStreamChunk 把该上游流式响应中的一个数据块转换成 OpenAI 格式的 chunk。
+	// ok 为 false 表示这个数据块不需要转发给客户端（例如心跳或元数据事件）。
+	TranslateStreamChunk(chunk []byte) (translated []byte, ok bool, err error)
+	// UpstreamPath 返回该上游自己的 REST 端点路径，用于替换客户端请求的 OpenAI 路径。
+	// 返回空字符串表示不需要改写（沿用客户端原始请求路径，即 passthrough 场景）。
+	UpstreamPath() string
+}
+
+// passthroughTranslator 原样转发，用于默认的 OpenAI 上游。
+type passthroughTranslator struct{}
+
+func (passthroughTranslator) TranslateRequestBody(body []byte) ([]byte, error) { return body, nil }
+func (passthroughTranslator) TranslateResponseBody(body []byte) ([]byte, error) {
+	return body, nil
+}
+func (passthroughTranslator) TranslateStreamChunk(chunk []byte) ([]byte, bool, error) {
+	return chunk, true, nil
+}
+func (passthroughTranslator) UpstreamPath() string { return "" }
+
+// openAIMessage 是 OpenAI chat-completions 里 messages 数组的一项。
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ---- Anthropic ----
+
+type anthropicTranslator struct{}
+
+func (anthropicTranslator) TranslateRequestBody(body []byte) ([]byte, error) {
+	var req struct {
+		Model       string          `json:"model"`
+		Messages    []openAIMessage `json:"messages"`
+		Temperature *float64        `json:"temperature,omitempty"`
+		TopP        *float64        `json:"top_p,omitempty"`
+		MaxTokens   *int            `json:"max_tokens,omitempty"`
+		Stream      bool            `json:"stream,omitempty"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+
+	var system []string
+	messages := make([]openAIMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+			continue
+		}
+		messages = append(messages, m)
+	}
+
+	maxTokens := 1024
+	if req.MaxTokens != nil {
+		maxTokens = *req.MaxTokens
+	}
+
+	out := map[string]interface{}{
+		"model":      req.Model,
+		"messages":   messages,
+		"max_tokens": maxTokens,
+		"stream":     req.Stream,
+	}
+	if len(system) > 0 {
+		out["system"] = string(bytes.Join(toByteSlices(system), []byte("\n\n")))
+	}
+	if req.Temperature != nil {
+		out["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		out["top_p"] = *req.TopP
+	}
+
+	return json.Marshal(out)
+}
+
+func toByteSlices(in []string) [][]byte {
+	out := make([][]byte, len(in))
+	for i, s := range in {
+		out[i] = []byte(s)
+	}
+	return out
+}
+
+func (anthropicTranslator) TranslateResponseBody(body []byte) ([]byte, error) {
+	var resp struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	var text bytes.Buffer
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	out := map[string]interface{}{
+		"id":     resp.ID,
+		"object": "chat.completion",
+		"model":  resp.Model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"message":       openAIMessage{Role: "assistant", Content: text.String()},
+			"finish_reason": anthropicStopReason(resp.StopReason),
+		}},
+		"usage": map[string]interface{}{
+			"prompt_tokens":     resp.Usage.InputTokens,
+			"completion_tokens": resp.Usage.OutputTokens,
+			"total_tokens":      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+	return json.Marshal(out)
+}
+
+func (anthropicTranslator) UpstreamPath() string { return "/v1/messages" }
+
+func anthropicStopReason(reason string) string {
+	if reason == "end_turn" || reason == "" {
+		return "stop"
+	}
+	return reason
+}
+
+func (anthropicTranslator) TranslateStreamChunk(chunk []byte) ([]byte, bool, error) {
+	var event struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"delta"`
+	}
+	if err := json.Unmarshal(chunk, &event); err != nil {
+		return nil, false, err
+	}
+
+	switch event.Type {
+	case "content_block_delta":
+		out := map[string]interface{}{
+			"object": "chat.completion.chunk",
+			"choices": []map[string]interface{}{{
+				"index": 0,
+				"delta": map[string]interface{}{"content": event.Delta.Text},
+			}},
+		}
+		translated, err := json.Marshal(out)
+		return translated, true, err
+	case "message_stop":
+		return streamDone, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// ---- Ollama ----
+
+type ollamaTranslator struct{}
+
+func (ollamaTranslator) TranslateRequestBody(body []byte) ([]byte, error) {
+	var req struct {
+		Model    string          `json:"model"`
+		Messages []openAIMessage `json:"messages"`
+		Stream   bool            `json:"stream,omitempty"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{
+		"model":    req.Model,
+		"messages": req.Messages,
+		"stream":   req.Stream,
+	}
+	return json.Marshal(out)
+}
+
+func (ollamaTranslator) TranslateResponseBody(body []byte) ([]byte, error) {
+	var resp struct {
+		Model   string        `json:"model"`
+		Message openAIMessage `json:"message"`
+		Done    bool          `json:"done"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	out := map[string]interface{}{
+		"object": "chat.completion",
+		"model":  resp.Model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"message":       resp.Message,
+			"finish_reason": "stop",
+		}},
+	}
+	return json.Marshal(out)
+}
+
+func (ollamaTranslator) TranslateStreamChunk(chunk []byte) ([]byte, bool, error) {
+	var event struct {
+		Model   string        `json:"model"`
+		Message openAIMessage `json:"message"`
+		Done    bool          `json:"done"`
+	}
+	if err := json.Unmarshal(chunk, &event); err != nil {
+		return nil, false, err
+	}
+
+	if event.Done {
+		return streamDone, true, nil
+	}
+
+	out := map[string]interface{}{
+		"object": "chat.completion.chunk",
+		"choices": []map[string]interface{}{{
+			"index": 0,
+			"delta": map[string]interface{}{"content": event.Message.Content},
+		}},
+	}
+	translated, err := json.Marshal(out)
+	return translated, true, err
+}
+
+func (ollamaTranslator) UpstreamPath() string { return "/api/chat" }
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAnthropicTranslateRequestBody(t *testing.T) {
+	in := []byte(`{"model":"claude-3","messages":[{"role":"system","content":"be nice"},{"role":"user","content":"hi"}],"temperature":0.2,"top_p":0.9}`)
+
+	out, err := anthropicTranslator{}.TranslateRequestBody(in)
+	if err != nil {
+		t.Fatalf("TranslateRequestBody 出错: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("转换结果不是合法 JSON: %v", err)
+	}
+
+	if got["system"] != "be nice" {
+		t.Fatalf("system 字段 = %#v, 期望字符串 %q（不能是 base64 编码的字节数组）", got["system"], "be nice")
+	}
+	messages, ok := got["messages"].([]interface{})
+	if !ok || len(messages) != 1 {
+		t.Fatalf("system 角色的消息应该被搬到 system 字段，剩余 messages = %#v", got["messages"])
+	}
+	if got["max_tokens"] != float64(1024) {
+		t.Fatalf("max_tokens 默认值 = %#v, 期望 1024", got["max_tokens"])
+	}
+	if got["temperature"] != 0.2 {
+		t.Fatalf("temperature = %#v, 期望 0.2", got["temperature"])
+	}
+}
+
+func TestAnthropicTranslateResponseBody(t *testing.T) {
+	in := []byte(`{"id":"msg_1","model":"claude-3","content":[{"type":"text","text":"hello"}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":4}}`)
+
+	out, err := anthropicTranslator{}.TranslateResponseBody(in)
+	if err != nil {
+		t.Fatalf("TranslateResponseBody 出错: %v", err)
+	}
+
+	var got struct {
+		Choices []struct {
+			Message      openAIMessage `json:"message"`
+			FinishReason string        `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("转换结果不是合法 JSON: %v", err)
+	}
+
+	if len(got.Choices) != 1 || got.Choices[0].Message.Content != "hello" {
+		t.Fatalf("choices 内容不符合预期: %#v", got.Choices)
+	}
+	if got.Choices[0].FinishReason != "stop" {
+		t.Fatalf("finish_reason = %q, 期望 Anthropic 的 end_turn 被映射为 stop", got.Choices[0].FinishReason)
+	}
+	if got.Usage.PromptTokens != 10 || got.Usage.CompletionTokens != 4 {
+		t.Fatalf("usage 转换不符合预期: %#v", got.Usage)
+	}
+}
+
+func TestAnthropicStopReason(t *testing.T) {
+	cases := map[string]string{
+		"end_turn":      "stop",
+		"":              "stop",
+		"max_tokens":    "max_tokens",
+		"tool_use":      "tool_use",
+		"stop_sequence": "stop_sequence",
+	}
+	for reason, want := range cases {
+		if got := anthropicStopReason(reason); got != want {
+			t.Errorf("anthropicStopReason(%q) = %q, 期望 %q", reason, got, want)
+		}
+	}
+}
+
+func TestAnthropicTranslateStreamChunk(t *testing.T) {
+	delta := []byte(`{"type":"content_block_delta","delta":{"type":"text_delta","text":"hi"}}`)
+	translated, ok, err := anthropicTranslator{}.TranslateStreamChunk(delta)
+	if err != nil || !ok {
+		t.Fatalf("content_block_delta 应该被转换并转发，ok=%v err=%v", ok, err)
+	}
+	var chunk struct {
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(translated, &chunk); err != nil {
+		t.Fatalf("转换结果不是合法 JSON: %v", err)
+	}
+	if len(chunk.Choices) != 1 || chunk.Choices[0].Delta.Content != "hi" {
+		t.Fatalf("delta 内容不符合预期: %#v", chunk.Choices)
+	}
+
+	stop := []byte(`{"type":"message_stop"}`)
+	translated, ok, err = anthropicTranslator{}.TranslateStreamChunk(stop)
+	if err != nil || !ok || string(translated) != string(streamDone) {
+		t.Fatalf("message_stop 应该映射成流结束哨兵值，got=%q ok=%v err=%v", translated, ok, err)
+	}
+
+	ping := []byte(`{"type":"ping"}`)
+	_, ok, err = anthropicTranslator{}.TranslateStreamChunk(ping)
+	if err != nil || ok {
+		t.Fatalf("未知事件类型不应该被转发，ok=%v err=%v", ok, err)
+	}
+}
+
+func TestAnthropicUpstreamPath(t *testing.T) {
+	if got := (anthropicTranslator{}).UpstreamPath(); got != "/v1/messages" {
+		t.Fatalf("UpstreamPath() = %q, 期望 %q", got, "/v1/messages")
+	}
+}
+
+func TestOllamaTranslateRequestBody(t *testing.T) {
+	in := []byte(`{"model":"llama3","messages":[{"role":"user","content":"hi"}],"stream":true}`)
+
+	out, err := ollamaTranslator{}.TranslateRequestBody(in)
+	if err != nil {
+		t.Fatalf("TranslateRequestBody 出错: %v", err)
+	}
+
+	var got struct {
+		Model    string          `json:"model"`
+		Messages []openAIMessage `json:"messages"`
+		Stream   bool            `json:"stream"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("转换结果不是合法 JSON: %v", err)
+	}
+	if got.Model != "llama3" || len(got.Messages) != 1 || !got.Stream {
+		t.Fatalf("转换结果不符合预期: %#v", got)
+	}
+}
+
+func TestOllamaTranslateResponseBody(t *testing.T) {
+	in := []byte(`{"model":"llama3","message":{"role":"assistant","content":"hello"},"done":true}`)
+
+	out, err := ollamaTranslator{}.TranslateResponseBody(in)
+	if err != nil {
+		t.Fatalf("TranslateResponseBody 出错: %v", err)
+	}
+
+	var got struct {
+		Choices []struct {
+			Message      openAIMessage `json:"message"`
+			FinishReason string        `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("转换结果不是合法 JSON: %v", err)
+	}
+	if len(got.Choices) != 1 || got.Choices[0].Message.Content != "hello" || got.Choices[0].FinishReason != "stop" {
+		t.Fatalf("转换结果不符合预期: %#v", got.Choices)
+	}
+}
+
+func TestOllamaTranslateStreamChunk(t *testing.T) {
+	chunk := []byte(`{"model":"llama3","message":{"role":"assistant","content":"hi"},"done":false}`)
+	translated, ok, err := ollamaTranslator{}.TranslateStreamChunk(chunk)
+	if err != nil || !ok {
+		t.Fatalf("未结束的数据块应该被转换并转发，ok=%v err=%v", ok, err)
+	}
+	var got struct {
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(translated, &got); err != nil {
+		t.Fatalf("转换结果不是合法 JSON: %v", err)
+	}
+	if len(got.Choices) != 1 || got.Choices[0].Delta.Content != "hi" {
+		t.Fatalf("delta 内容不符合预期: %#v", got.Choices)
+	}
+
+	done := []byte(`{"model":"llama3","message":{"role":"assistant","content":""},"done":true}`)
+	translated, ok, err = ollamaTranslator{}.TranslateStreamChunk(done)
+	if err != nil || !ok || string(translated) != string(streamDone) {
+		t.Fatalf("done=true 应该映射成流结束哨兵值，got=%q ok=%v err=%v", translated, ok, err)
+	}
+}
+
+func TestOllamaUpstreamPath(t *testing.T) {
+	if got := (ollamaTranslator{}).UpstreamPath(); got != "/api/chat" {
+		t.Fatalf("UpstreamPath() = %q, 期望 %q", got, "/api/chat")
+	}
+}
+
+func TestPassthroughTranslatorDoesNotModify(t *testing.T) {
+	pt := passthroughTranslator{}
+	body := []byte(`{"anything":"goes"}`)
+
+	if out, err := pt.TranslateRequestBody(body); err != nil || string(out) != string(body) {
+		t.Fatalf("passthrough 不应该修改请求体")
+	}
+	if out, err := pt.TranslateResponseBody(body); err != nil || string(out) != string(body) {
+		t.Fatalf("passthrough 不应该修改响应体")
+	}
+	if out, ok, err := pt.TranslateStreamChunk(body); err != nil || !ok || string(out) != string(body) {
+		t.Fatalf("passthrough 不应该修改流式数据块")
+	}
+	if got := pt.UpstreamPath(); got != "" {
+		t.Fatalf("passthrough 的 UpstreamPath() = %q, 期望空字符串（沿用客户端原始路径）", got)
+	}
+}